@@ -2,13 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
-	"os"
-	"os/signal"
+	"log/slog"
 	"sync"
-	"syscall"
 	"time"
+
+	"goroutines_version/logging"
+	"goroutines_version/service"
+)
+
+// WaitMode selects the Chandy-Misra-Haas variant a Process waits under:
+// AND declares a deadlock as soon as any one probe it sent returns, OR
+// only once every successor it sent a probe to has returned one.
+type WaitMode int
+
+const (
+	ModeAND WaitMode = iota
+	ModeOR
 )
 
 type Config struct {
@@ -16,8 +27,12 @@ type Config struct {
 	ChanBufferSize  int
 	SimulationTime  time.Duration
 	TimeoutDuration time.Duration
+	Model           WaitMode
 }
 
+// Probe carries (Initiator, Sender, target-implicit-in-channel): Initiator
+// is the process that started the session, Sender is whichever process
+// last forwarded it.
 type Probe struct {
 	SessionID int
 	Initiator int
@@ -26,46 +41,65 @@ type Probe struct {
 	AckChan   chan bool
 }
 
+// Process is a service.Service: ctx.Done() is the only shutdown signal it
+// understands, so there is no stopChan; ackWG only lets Serve wait for
+// sendProbe's ack-collector goroutines before it returns.
 type Process struct {
-	ID            int
-	successors    []*Process
-	probeChan     chan Probe
-	visited       map[int]bool
-	config        Config
-	mu            sync.Mutex
-	wg            sync.WaitGroup
-	stopChan      chan struct{}
-	messagesSent  int
-	deadlockCache map[int]bool
+	ID              int
+	successors      []*Process
+	probeChan       chan Probe
+	visited         map[int]bool
+	config          Config
+	logger          *slog.Logger
+	mu              sync.Mutex
+	messagesSent    int
+	deadlockCache   map[int]bool
+	returnsBySession map[int]int // OR model: confirmations received per session
+	ackWG           sync.WaitGroup // tracks sendProbe's ack-collector goroutines
 }
 
-func NewProcess(id int, config Config) *Process {
+func NewProcess(id int, config Config, logger *slog.Logger) *Process {
 	return &Process{
-		ID:            id,
-		probeChan:     make(chan Probe, config.ChanBufferSize),
-		visited:       make(map[int]bool),
-		deadlockCache: make(map[int]bool),
-		config:        config,
-		stopChan:      make(chan struct{}),
+		ID:               id,
+		probeChan:        make(chan Probe, config.ChanBufferSize),
+		visited:          make(map[int]bool),
+		deadlockCache:    make(map[int]bool),
+		returnsBySession: make(map[int]int),
+		config:           config,
+		logger:           logger,
 	}
 }
 
-func (p *Process) Run(ctx context.Context) {
-	// Log the start of deadlock detection for this process.
-	log.Printf("Starting deadlock detection from Process %d, Visited: [%d]", p.ID, p.ID)
-	p.wg.Add(1)
-	defer p.wg.Done()
+func (p *Process) String() string {
+	return fmt.Sprintf("process-%d", p.ID)
+}
+
+// Serve runs the probe ticker and the probe handler together, returning
+// only once ctx is cancelled.
+func (p *Process) Serve(ctx context.Context) error {
+	ctx = logging.WithContext(ctx, logging.Fields{Component: "probe", Initiator: logging.ID(p.ID)})
+	logging.Info(ctx, p.logger, "starting deadlock detection")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.handleProbes(ctx)
+	}()
 
+	p.runTicker(ctx)
+	wg.Wait()
+	p.ackWG.Wait()
+	return nil
+}
+
+func (p *Process) runTicker(ctx context.Context) {
 	ticker := time.NewTicker(p.config.ProbeInterval)
 	defer ticker.Stop()
 
-	p.wg.Add(1)
-	go p.handleProbes(ctx)
-
 	for {
 		select {
 		case <-ctx.Done():
-			close(p.stopChan)
 			return
 		case <-ticker.C:
 			p.mu.Lock()
@@ -80,10 +114,13 @@ func (p *Process) Run(ctx context.Context) {
 					Visited:   []int{p.ID},
 					AckChan:   make(chan bool, len(p.successors)),
 				}
-				// Log initiation of probe including visited list.
-				log.Printf("Probe sent from Process %d to Process %d for origin %d, Visited: %v", p.ID, p.successors[0].ID, p.ID, probe.Visited)
+				sessionCtx := logging.WithContext(ctx, logging.Fields{
+					Component: "probe", SessionID: logging.ID(sessionID), Initiator: logging.ID(p.ID),
+					Sender: logging.ID(p.ID), Target: logging.ID(p.successors[0].ID), Origin: logging.ID(p.ID), Visited: probe.Visited,
+				})
+				logging.Trace(sessionCtx, p.logger, "probe sent")
 				p.mu.Unlock()
-				p.sendProbe(probe, ctx)
+				p.sendProbe(sessionCtx, probe)
 			} else {
 				p.mu.Unlock()
 			}
@@ -92,14 +129,18 @@ func (p *Process) Run(ctx context.Context) {
 }
 
 func (p *Process) handleProbes(ctx context.Context) {
-	defer p.wg.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case probe := <-p.probeChan:
+			probeCtx := logging.WithContext(ctx, logging.Fields{
+				Component: "probe", SessionID: logging.ID(probe.SessionID), Initiator: logging.ID(probe.Initiator),
+				Sender: logging.ID(probe.Sender), Target: logging.ID(p.ID), Origin: logging.ID(probe.Initiator), Visited: probe.Visited,
+			})
+
 			p.mu.Lock()
-			//cknowledge receipt of the probe.
+			// Acknowledge receipt of the probe.
 			select {
 			case probe.AckChan <- true:
 			default:
@@ -107,10 +148,21 @@ func (p *Process) handleProbes(ctx context.Context) {
 
 			// Check for deadlock: if the probe returns to its initiator.
 			if probe.Initiator == p.ID && !p.deadlockCache[probe.SessionID] {
-				log.Printf("Deadlock detected involving process %d, Visited: %v", p.ID, probe.Visited)
+				p.returnsBySession[probe.SessionID]++
+				required := 1
+				if p.config.Model == ModeOR {
+					required = len(p.successors)
+				}
+				if p.returnsBySession[probe.SessionID] < required {
+					// OR model: still waiting on the rest of this session's
+					// branches to confirm before declaring a deadlock.
+					p.mu.Unlock()
+					logging.Trace(probeCtx, p.logger, "probe returned, awaiting quorum")
+					continue
+				}
 				p.deadlockCache[probe.SessionID] = true
 				p.mu.Unlock()
-				log.Println("Deadlock confirmed!")
+				logging.Info(probeCtx, p.logger, "deadlock confirmed")
 				continue
 			}
 			// Forward the probe if not already processed.
@@ -123,10 +175,13 @@ func (p *Process) handleProbes(ctx context.Context) {
 					Visited:   append(probe.Visited, p.ID),
 					AckChan:   make(chan bool, len(p.successors)),
 				}
-				// Log forwarding of probe with the updated visited list.
-				log.Printf("Probe sent from Process %d to Process %d for origin %d, Visited: %v", p.ID, p.successors[0].ID, probe.Initiator, newProbe.Visited)
+				forwardCtx := logging.WithContext(ctx, logging.Fields{
+					Component: "probe", SessionID: logging.ID(probe.SessionID), Initiator: logging.ID(probe.Initiator),
+					Sender: logging.ID(p.ID), Target: logging.ID(p.successors[0].ID), Origin: logging.ID(probe.Initiator), Visited: newProbe.Visited,
+				})
+				logging.Trace(forwardCtx, p.logger, "probe forwarded")
 				p.mu.Unlock()
-				p.sendProbe(newProbe, ctx)
+				p.sendProbe(forwardCtx, newProbe)
 			} else {
 				p.mu.Unlock()
 			}
@@ -134,13 +189,7 @@ func (p *Process) handleProbes(ctx context.Context) {
 	}
 }
 
-func (p *Process) sendProbe(probe Probe, ctx context.Context) {
-	select {
-	case <-p.stopChan:
-		return
-	default:
-	}
-
+func (p *Process) sendProbe(ctx context.Context, probe Probe) {
 	p.mu.Lock()
 	// Copy the list of successors to avoid race conditions.
 	successors := make([]*Process, len(p.successors))
@@ -161,8 +210,12 @@ func (p *Process) sendProbe(probe Probe, ctx context.Context) {
 		}
 	}
 
-	// Wait for acknowledgments in a separate goroutine.
+	// Wait for acknowledgments in a separate goroutine, tracked by ackWG so
+	// Serve doesn't return - and the Supervisor doesn't consider this
+	// Process stopped - while one is still draining AckChan.
+	p.ackWG.Add(1)
 	go func(expected int) {
+		defer p.ackWG.Done()
 		acks := 0
 		for acks < expected {
 			select {
@@ -178,6 +231,12 @@ func (p *Process) sendProbe(probe Probe, ctx context.Context) {
 }
 
 func main() {
+	logLevel := flag.String("log-level", "info", "trace|debug|info|warn|error")
+	logFormat := flag.String("log-format", "text", "text|json")
+	flag.Parse()
+
+	logger := logging.New(*logLevel, *logFormat)
+
 	config := Config{
 		ProbeInterval:   10 * time.Second,
 		ChanBufferSize:  20,
@@ -186,10 +245,10 @@ func main() {
 	}
 
 	// Create processes with cyclic dependencies: p0 → p1 → p2 → p3 → p0.
-	p0 := NewProcess(0, config)
-	p1 := NewProcess(1, config)
-	p2 := NewProcess(2, config)
-	p3 := NewProcess(3, config)
+	p0 := NewProcess(0, config, logger)
+	p1 := NewProcess(1, config, logger)
+	p2 := NewProcess(2, config, logger)
+	p3 := NewProcess(3, config, logger)
 
 	p0.successors = []*Process{p1}
 	p1.successors = []*Process{p2}
@@ -199,27 +258,24 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Listen for shutdown signals.
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sup := service.NewSupervisor(logger, p0, p1, p2, p3, service.NewSignalService(cancel))
 
-	go p0.Run(ctx)
-	go p1.Run(ctx)
-	go p2.Run(ctx)
-	go p3.Run(ctx)
+	done := make(chan error, 1)
+	go func() { done <- sup.Run(ctx) }()
 
+	var runErr error
 	select {
 	case <-time.After(config.SimulationTime):
-		log.Println("Simulation completed")
-	case <-sigChan:
-		log.Println("Shutdown signal received")
+		logger.Info("simulation completed")
+		cancel()
+		runErr = <-done
+	case runErr = <-done:
+		logger.Info("shutdown signal received")
 	}
-	cancel()
 
-	p0.wg.Wait()
-	p1.wg.Wait()
-	p2.wg.Wait()
-	p3.wg.Wait()
+	if runErr != nil {
+		logger.Error("supervisor stopped with error", "error", runErr)
+	}
 
 	fmt.Printf("Final Metrics: P0: %d messages sent, P1: %d messages sent, P2: %d messages sent, P3: %d messages sent\n",
 		p0.messagesSent, p1.messagesSent, p2.messagesSent, p3.messagesSent)
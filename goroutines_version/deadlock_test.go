@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"goroutines_version/logging"
+	"goroutines_version/service"
+)
+
+// TestProcessServeCleansUpOnCancel runs a small cyclic ring of real
+// Processes under a real Supervisor, cancels it, and verifies no
+// goroutine survives - in particular sendProbe's ack-collector, which is
+// spawned outside the WaitGroup Serve itself waits on and would leak if
+// ackWG didn't track it too.
+func TestProcessServeCleansUpOnCancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	logger := logging.New("error", "text")
+	config := Config{
+		ProbeInterval:   5 * time.Millisecond,
+		ChanBufferSize:  4,
+		TimeoutDuration: 50 * time.Millisecond,
+	}
+
+	p0 := NewProcess(0, config, logger)
+	p1 := NewProcess(1, config, logger)
+	p0.successors = []*Process{p1}
+	p1.successors = []*Process{p0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sup := service.NewSupervisor(logger, p0, p1)
+
+	done := make(chan error, 1)
+	go func() { done <- sup.Run(ctx) }()
+
+	// Let a few probe ticks fire so sendProbe's ack-collector goroutines
+	// are actually in flight when we cancel.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Supervisor.Run returned unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Supervisor.Run did not return after cancellation")
+	}
+}
+
+// startHandlers runs handleProbes for every given Process under ctx and
+// returns a func that blocks until all of them have returned, so a test
+// can drive probe forwarding directly without the real ticker/sendProbe
+// timing, then confirm every goroutine it started is gone.
+func startHandlers(ctx context.Context, procs ...*Process) func() {
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func(p *Process) {
+			defer wg.Done()
+			p.handleProbes(ctx)
+		}(p)
+	}
+	return wg.Wait
+}
+
+// seedProbe marks sessionID as already sent by initiator, mirroring what
+// runTicker does before handing a probe to sendProbe, and returns the
+// Probe a test can push directly onto initiator's successors' channels.
+func seedProbe(initiator *Process, sessionID int) Probe {
+	initiator.mu.Lock()
+	initiator.visited[sessionID] = true
+	initiator.mu.Unlock()
+	return Probe{
+		SessionID: sessionID,
+		Initiator: initiator.ID,
+		Sender:    initiator.ID,
+		Visited:   []int{initiator.ID},
+		AckChan:   make(chan bool, len(initiator.successors)),
+	}
+}
+
+// waitForDeadlock polls p's deadlockCache for sessionID, failing tb if it
+// never confirms within a second.
+func waitForDeadlock(tb testing.TB, p *Process, sessionID int) {
+	tb.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		done := p.deadlockCache[sessionID]
+		p.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	tb.Fatalf("%s never confirmed deadlock for session %d", p, sessionID)
+}
+
+// requireNoDeadlock asserts p has not confirmed sessionID after giving
+// the forwarding goroutines a chance to run.
+func requireNoDeadlock(t *testing.T, p *Process, sessionID int) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.deadlockCache[sessionID] {
+		t.Fatalf("%s falsely confirmed deadlock for session %d", p, sessionID)
+	}
+}
+
+func TestHandleProbesDetectsCycles(t *testing.T) {
+	newProc := func(id int, model WaitMode) *Process {
+		return NewProcess(id, Config{ChanBufferSize: 4, Model: model}, logging.New("error", "text"))
+	}
+
+	t.Run("nested cycle closes only at the outer initiator", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		// p0 -> p1 -> p2 -> {p1, p0}: p2 closes an inner p1<->p2 cycle that
+		// p1 already ignores (visited), and separately closes the outer
+		// cycle back to p0, the session's AND-mode initiator.
+		p0 := newProc(0, ModeAND)
+		p1 := newProc(1, ModeAND)
+		p2 := newProc(2, ModeAND)
+		p0.successors = []*Process{p1}
+		p1.successors = []*Process{p2}
+		p2.successors = []*Process{p1, p0}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wait := startHandlers(ctx, p0, p1, p2)
+
+		probe := seedProbe(p0, 1)
+		p1.probeChan <- probe
+
+		waitForDeadlock(t, p0, 1)
+
+		cancel()
+		wait()
+	})
+
+	t.Run("disjoint cycle does not affect an unrelated process", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+
+		// p3<->p4 is its own 2-cycle; p5 -> p6 is a plain chain with no
+		// cycle at all and must never confirm a deadlock.
+		p3 := newProc(3, ModeAND)
+		p4 := newProc(4, ModeAND)
+		p5 := newProc(5, ModeAND)
+		p6 := newProc(6, ModeAND)
+		p3.successors = []*Process{p4}
+		p4.successors = []*Process{p3}
+		p5.successors = []*Process{p6}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wait := startHandlers(ctx, p3, p4, p5, p6)
+
+		probe := seedProbe(p3, 1)
+		p4.probeChan <- probe
+
+		waitForDeadlock(t, p3, 1)
+		requireNoDeadlock(t, p5, 1)
+
+		cancel()
+		wait()
+	})
+}
+
+// TestHandleProbesORRequiresAllSuccessorsToConfirm exercises the
+// multi-successor OR-quorum branch of handleProbes (required :=
+// len(p.successors)): p0 fans its probe out to two independent branches
+// that both loop back to it, and a deadlock should be confirmed only
+// once both have returned, not after the first.
+func TestHandleProbesORRequiresAllSuccessorsToConfirm(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	p0 := NewProcess(0, Config{ChanBufferSize: 4, Model: ModeOR}, logging.New("error", "text"))
+	p1 := NewProcess(1, Config{ChanBufferSize: 4, Model: ModeOR}, logging.New("error", "text"))
+	p2 := NewProcess(2, Config{ChanBufferSize: 4, Model: ModeOR}, logging.New("error", "text"))
+	p0.successors = []*Process{p1, p2}
+	p1.successors = []*Process{p0}
+	p2.successors = []*Process{p0}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := startHandlers(ctx, p0, p1, p2)
+
+	probe := seedProbe(p0, 1)
+
+	// Only the p1 branch has returned so far: one confirmation is not a
+	// quorum of two, so p0 must not declare a deadlock yet.
+	p1.probeChan <- probe
+	requireNoDeadlock(t, p0, 1)
+
+	// Once p2's branch also returns, both of p0's successors have
+	// confirmed and the deadlock should be declared.
+	p2.probeChan <- probe
+	waitForDeadlock(t, p0, 1)
+
+	cancel()
+	wait()
+}
+
+// BenchmarkProbeMessageCount compares how many probe messages the
+// initiator p0 sends per detection round under AND (first branch back
+// wins) versus OR (every branch must confirm) semantics on the same
+// branching topology; messages forwarded by p1 and p2 are not counted.
+// It mirrors rpc_version/detector's BenchmarkDetectChain for this model.
+func BenchmarkProbeMessageCount(b *testing.B) {
+	for _, mode := range []WaitMode{ModeAND, ModeOR} {
+		mode := mode
+		b.Run(modeName(mode), func(b *testing.B) {
+			logger := logging.New("error", "text")
+			config := Config{ChanBufferSize: 8, TimeoutDuration: 200 * time.Millisecond, Model: mode}
+
+			p0 := NewProcess(0, config, logger)
+			p1 := NewProcess(1, config, logger)
+			p2 := NewProcess(2, config, logger)
+			p0.successors = []*Process{p1, p2}
+			p1.successors = []*Process{p0}
+			p2.successors = []*Process{p0}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wait := startHandlers(ctx, p0, p1, p2)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				probe := seedProbe(p0, i)
+				p0.sendProbe(ctx, probe)
+				waitForDeadlock(b, p0, i)
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(p0.messagesSent)/float64(b.N), "messages/op")
+
+			cancel()
+			wait()
+		})
+	}
+}
+
+func modeName(mode WaitMode) string {
+	if mode == ModeOR {
+		return "OR"
+	}
+	return "AND"
+}
@@ -0,0 +1,83 @@
+// Package service provides a small, context-first lifecycle for the long
+// running goroutines in the probe simulator, replacing ad-hoc
+// stopChan/WaitGroup plumbing with a single root context and a Supervisor
+// that starts, waits for, and reports on a named set of them.
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Service is anything the Supervisor can run for the lifetime of a root
+// context. Serve must return once ctx is done; it is the only signal a
+// Service ever needs to shut down.
+type Service interface {
+	Serve(ctx context.Context) error
+	String() string
+}
+
+// Supervisor runs a fixed set of named services under one root context,
+// waits for all of them to return, and aggregates their errors.
+type Supervisor struct {
+	services []Service
+	logger   *slog.Logger
+}
+
+// NewSupervisor builds a Supervisor over the given services, logging
+// their start/stop through logger so Run's own lines honor the same
+// --log-level/--log-format flags as everything else in the process.
+func NewSupervisor(logger *slog.Logger, services ...Service) *Supervisor {
+	return &Supervisor{services: services, logger: logger}
+}
+
+// Run starts every service in its own goroutine, logging start/stop, and
+// blocks until all of them have returned. If ctx is cancelled, Serve is
+// expected to notice ctx.Done() and return promptly; Run itself never
+// cancels ctx, that is the caller's job.
+func (s *Supervisor) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(s.services))
+
+	for i, svc := range s.services {
+		wg.Add(1)
+		go func(i int, svc Service) {
+			defer wg.Done()
+			s.logger.Info("service starting", "service", svc.String())
+			err := svc.Serve(ctx)
+			if err != nil {
+				s.logger.Error("service stopped with error", "service", svc.String(), "error", err)
+			} else {
+				s.logger.Info("service stopped", "service", svc.String())
+			}
+			errs[i] = err
+		}(i, svc)
+	}
+
+	wg.Wait()
+	return aggregate(errs)
+}
+
+func aggregate(errs []error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &MultiError{Errs: nonNil}
+}
+
+// MultiError collects the errors returned by every Service that failed.
+type MultiError struct {
+	Errs []error
+}
+
+func (m *MultiError) Error() string {
+	return fmt.Sprintf("%d service(s) failed: %v", len(m.Errs), m.Errs)
+}
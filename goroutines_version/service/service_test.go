@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+
+	"goroutines_version/logging"
+)
+
+type fakeService struct {
+	name string
+	err  error
+}
+
+func (f *fakeService) String() string { return f.name }
+
+func (f *fakeService) Serve(ctx context.Context) error {
+	<-ctx.Done()
+	return f.err
+}
+
+func TestSupervisorRunCancelsCleanly(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sup := NewSupervisor(logging.New("error", "text"), &fakeService{name: "a"}, &fakeService{name: "b"})
+
+	done := make(chan error, 1)
+	go func() { done <- sup.Run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run returned unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Supervisor.Run did not return after cancellation")
+	}
+}
+
+func TestSupervisorRunAggregatesErrors(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	boom := errors.New("boom")
+	sup := NewSupervisor(logging.New("error", "text"), &fakeService{name: "ok"}, &fakeService{name: "bad", err: boom})
+
+	done := make(chan error, 1)
+	go func() { done <- sup.Run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		var multi *MultiError
+		if !errors.As(err, &multi) {
+			t.Fatalf("expected *MultiError, got %v (%T)", err, err)
+		}
+		if len(multi.Errs) != 1 {
+			t.Fatalf("expected 1 failed service, got %d", len(multi.Errs))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Supervisor.Run did not return after cancellation")
+	}
+}
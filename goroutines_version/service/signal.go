@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// SignalService cancels the Supervisor's root context as soon as the
+// process receives SIGINT or SIGTERM, so every other Service shuts down
+// the same way it would on any other cancellation.
+type SignalService struct {
+	cancel context.CancelFunc
+}
+
+// NewSignalService builds a SignalService that calls cancel on SIGINT or
+// SIGTERM.
+func NewSignalService(cancel context.CancelFunc) *SignalService {
+	return &SignalService{cancel: cancel}
+}
+
+func (s *SignalService) String() string { return "signal" }
+
+func (s *SignalService) Serve(ctx context.Context) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case <-sigCh:
+		s.cancel()
+	case <-ctx.Done():
+	}
+	return nil
+}
@@ -1,31 +1,39 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
+	"log/slog"
+
+	"goroutines_version/logging"
 )
 
 // Process represents a process in the system.
 type Process struct {
-	id            int
-	waitingFor    []int
+	id             int
+	waitingFor     []int
 	receivedProbes map[int]bool
 }
 
 // sendProbe sends a probe message to a dependent process.
-func sendProbe(probeSender int, origin int, target int, processes map[int]*Process) bool {
-	fmt.Printf("Probe sent from Process %d to Process %d for origin %d\n", probeSender, target, origin)
+func sendProbe(ctx context.Context, logger *slog.Logger, probeSender int, origin int, target int, processes map[int]*Process) bool {
+	ctx = logging.WithContext(ctx, logging.Fields{
+		Component: "cmh", SessionID: logging.ID(origin), Initiator: logging.ID(origin),
+		Sender: logging.ID(probeSender), Target: logging.ID(target), Origin: logging.ID(origin),
+	})
+	logging.Debug(ctx, logger, "probe sent")
 
 	// If the target process is waiting for other processes, forward the probe.
 	if len(processes[target].waitingFor) > 0 {
 		for _, dependent := range processes[target].waitingFor {
 			// Check if the probe has returned to the origin.
 			if dependent == origin {
-				fmt.Println("Deadlock detected involving process", origin)
+				logging.Info(ctx, logger, "deadlock detected")
 				return true
 			}
 			if !processes[target].receivedProbes[dependent] {
 				processes[target].receivedProbes[dependent] = true
-				if sendProbe(target, origin, dependent, processes) {
+				if sendProbe(ctx, logger, target, origin, dependent, processes) {
 					return true
 				}
 			}
@@ -35,21 +43,30 @@ func sendProbe(probeSender int, origin int, target int, processes map[int]*Proce
 }
 
 // detectDeadlock initiates deadlock detection from each process.
-func detectDeadlock(processes map[int]*Process) {
+func detectDeadlock(ctx context.Context, logger *slog.Logger, processes map[int]*Process) {
 	for id, process := range processes {
-		fmt.Println("\nStarting deadlock detection from Process", id)
+		sessionCtx := logging.WithContext(ctx, logging.Fields{
+			Component: "cmh", SessionID: logging.ID(id), Initiator: logging.ID(id),
+		})
+		logging.Info(sessionCtx, logger, "starting deadlock detection")
 		process.receivedProbes = make(map[int]bool) // Reset received probes.
 		for _, dependent := range process.waitingFor {
-			if sendProbe(id, id, dependent, processes) {
-				fmt.Println("Deadlock confirmed!")
+			if sendProbe(sessionCtx, logger, id, id, dependent, processes) {
+				logging.Info(sessionCtx, logger, "deadlock confirmed")
 				return
 			}
 		}
 	}
-	fmt.Println("No deadlock detected.")
+	logger.Info("no deadlock detected")
 }
 
 func main() {
+	logLevel := flag.String("log-level", "info", "trace|debug|info|warn|error")
+	logFormat := flag.String("log-format", "text", "text|json")
+	flag.Parse()
+
+	logger := logging.New(*logLevel, *logFormat)
+
 	// Initialize processes with a cyclic dependency.
 	processes := map[int]*Process{
 		1: {id: 1, waitingFor: []int{2}, receivedProbes: make(map[int]bool)},
@@ -58,5 +75,5 @@ func main() {
 	}
 
 	// Detect deadlock using the Chandy-Misra-Haas algorithm.
-	detectDeadlock(processes)
+	detectDeadlock(context.Background(), logger, processes)
 }
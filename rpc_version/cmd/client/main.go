@@ -0,0 +1,69 @@
+// Command client drives a handful of sample deadlock detection requests
+// against a node in the cluster, leader or follower.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"rpc_version/leaderclient"
+	"rpc_version/logging"
+	"rpc_version/pb"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:1234", "address of a node in the cluster (leader or follower)")
+	logLevel := flag.String("log-level", "info", "trace|debug|info|warn|error")
+	logFormat := flag.String("log-format", "text", "text|json")
+	flag.Parse()
+
+	logger := logging.New(*logLevel, *logFormat)
+
+	cli := leaderclient.New(*addr)
+	defer cli.Close()
+	ctx := context.Background()
+
+	// Optionally add a new process dynamically.
+	_, err := cli.AddProcess(ctx, &pb.AddProcessRequest{ID: 4, Neighbors: []int64{1}})
+	if err != nil {
+		logger.Warn("AddProcess failed", "error", err)
+	} else {
+		logger.Info("process added", "id", 4)
+	}
+
+	// Deadlock detection requests: each waiter asks whether it is part of
+	// a cycle, regardless of which node in the cluster currently holds the
+	// lease on the leader role.
+	waiters := []int64{0, 2, 3}
+
+	for _, waiter := range waiters {
+		startTime := time.Now()
+		ctx := logging.WithContext(ctx, logging.Fields{Component: "client", Initiator: logging.ID(waiter)})
+		logging.Info(ctx, logger, "sending deadlock detection request")
+
+		req := &pb.DeadlockRequest{
+			Type:       pb.DetectType_DETECT,
+			WaitForTxn: waiter,
+			LockTxn:    waiter + 1,
+		}
+		res, err := cli.Send(ctx, req)
+		if err != nil {
+			logger.Error("request failed", "error", err)
+			continue
+		}
+
+		timeTaken := time.Since(startTime)
+		logging.Info(ctx, logger, "response received from cluster")
+
+		fmt.Printf("\n[Result for Process %d]\n", waiter)
+		if res.DeadlockDetected {
+			fmt.Printf(" ➤ Deadlock Status: Deadlock confirmed! Victim: %d\n", res.Victim)
+		} else {
+			fmt.Printf(" ➤ Deadlock Status: No deadlock detected.\n")
+		}
+		fmt.Printf(" ➤ Key Hash: %d\n", res.KeyHash)
+		fmt.Printf(" ➤ Time Taken: %v\n\n", timeTaken)
+	}
+}
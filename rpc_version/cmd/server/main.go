@@ -0,0 +1,232 @@
+// Command server runs one node of the deadlock detector cluster: a leader
+// holding the global wait-for graph, or a follower that forwards every
+// request it cannot answer locally.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc"
+
+	"rpc_version/detector"
+	"rpc_version/leaderclient"
+	"rpc_version/logging"
+	"rpc_version/pb"
+	"rpc_version/store"
+)
+
+// DeadlockService implements pb.DeadlockDetectorServer. It is role-aware:
+// as a follower it simply relays every DeadlockRequest it receives from a
+// local client onto its outbound stream to the leader; as the leader it
+// applies the request to the global graph and runs DFS itself.
+type DeadlockService struct {
+	pb.UnimplementedDeadlockDetectorServer
+
+	detector *detector.Detector
+	logger   *slog.Logger
+
+	sessionSeq int64
+
+	mu        sync.Mutex
+	leaderCli *leaderclient.Client // nil while this node is itself the leader
+}
+
+func NewDeadlockService(leaderAddr string, s store.Store, logger *slog.Logger) *DeadlockService {
+	return &DeadlockService{detector: detector.NewDetector(leaderAddr, s), logger: logger}
+}
+
+// nextSessionID tags every Detect call reaching the leader with an
+// incrementing session ID so its forwarding and victim-selection log
+// lines can be grepped as one session.
+func (ds *DeadlockService) nextSessionID() int64 {
+	return atomic.AddInt64(&ds.sessionSeq, 1)
+}
+
+// ChangeRole is called by the cluster coordinator whenever leadership
+// changes. A follower redials leaderAddr; a newly promoted leader drops
+// its outbound connection since it now serves Detect locally.
+func (ds *DeadlockService) ChangeRole(ctx context.Context, req *pb.ChangeRoleRequest) (*pb.ChangeRoleResponse, error) {
+	ds.detector.ChangeRole(detector.Role(req.Role), req.LeaderAddr)
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.leaderCli != nil {
+		ds.leaderCli.Close()
+		ds.leaderCli = nil
+	}
+	if detector.Role(req.Role) == detector.RoleFollower {
+		ds.leaderCli = leaderclient.New(req.LeaderAddr)
+	}
+	return &pb.ChangeRoleResponse{}, nil
+}
+
+// AddProcess is accepted on any node; followers forward it to the leader
+// the same way they forward Detect requests, so the graph is only ever
+// mutated on the node that owns it.
+func (ds *DeadlockService) AddProcess(ctx context.Context, req *pb.AddProcessRequest) (*pb.AddProcessResponse, error) {
+	if ds.detector.Role() == detector.RoleLeader {
+		for _, n := range req.Neighbors {
+			if err := ds.detector.AddWaitFor(ctx, detector.Txn{ID: req.ID}, n); err != nil {
+				return nil, err
+			}
+		}
+		return &pb.AddProcessResponse{}, nil
+	}
+	return ds.currentLeaderClient().AddProcess(ctx, req)
+}
+
+// Detect is the persistent, bidirectional stream a follower's local
+// client dials once and reuses for every DetectRequest/CleanUp message;
+// on the leader it is served directly against the detector's graph store.
+func (ds *DeadlockService) Detect(stream pb.DeadlockDetector_DetectServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := ds.handle(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if res == nil {
+			continue
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (ds *DeadlockService) handle(ctx context.Context, req *pb.DeadlockRequest) (*pb.DeadlockResponse, error) {
+	sessionID := ds.nextSessionID()
+	ctx = logging.WithContext(ctx, logging.Fields{
+		Component: "server", SessionID: logging.ID(sessionID),
+		Initiator: logging.ID(req.WaitForTxn), Target: logging.ID(req.LockTxn), KeyHash: logging.Hash(req.KeyHash),
+	})
+
+	if ds.detector.Role() != detector.RoleLeader {
+		logging.Trace(ctx, ds.logger, "forwarding request to leader")
+		return ds.currentLeaderClient().Send(ctx, req)
+	}
+
+	switch req.Type {
+	case pb.DetectType_CLEAN_UP:
+		if err := ds.detector.CleanUp(ctx, req.WaitForTxn); err != nil {
+			return nil, err
+		}
+		logging.Debug(ctx, ds.logger, "cleaned up transaction")
+		return nil, nil
+	case pb.DetectType_CLEAN_UP_WAIT_FOR:
+		if err := ds.detector.RemoveWaitFor(ctx, req.WaitForTxn, req.LockTxn); err != nil {
+			return nil, err
+		}
+		logging.Debug(ctx, ds.logger, "removed wait-for edge")
+		return nil, nil
+	default: // pb.DetectType_DETECT
+		waiter := detector.Txn{ID: req.WaitForTxn, StartTS: req.StartTs, Priority: req.Priority}
+		if err := ds.detector.AddWaitFor(ctx, waiter, req.LockTxn); err != nil {
+			return nil, err
+		}
+		cycle, victim, found, err := ds.detector.Detect(ctx, req.WaitForTxn, detector.Mode(req.Mode))
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			logging.Trace(ctx, ds.logger, "no deadlock found")
+			return &pb.DeadlockResponse{KeyHash: req.KeyHash, RequestId: req.RequestId}, nil
+		}
+		cycleCtx := logging.WithContext(ctx, logging.Fields{
+			Component: "server", SessionID: logging.ID(sessionID), Initiator: logging.ID(req.WaitForTxn),
+			Visited: cycle, KeyHash: logging.Hash(detector.KeyHashFor(cycle)),
+		})
+		logging.Info(cycleCtx, ds.logger, "deadlock detected")
+		return &pb.DeadlockResponse{
+			KeyHash:          detector.KeyHashFor(cycle),
+			DeadlockDetected: true,
+			Victim:           victim,
+			Cycle:            cycle,
+			RequestId:        req.RequestId,
+		}, nil
+	}
+}
+
+// newStore builds the configured graph backend: inmem for a single-node
+// demo, redis when the graph needs to survive a restart or be shared
+// live across replicas.
+func newStore(kind, redisAddr, redisPassword string, redisDB int) (store.Store, error) {
+	switch kind {
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     redisAddr,
+			Password: redisPassword,
+			DB:       redisDB,
+		})
+		return store.NewRedisStore(client), nil
+	case "inmem", "":
+		return store.NewInMemStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown --store %q (want inmem or redis)", kind)
+	}
+}
+
+func (ds *DeadlockService) currentLeaderClient() *leaderclient.Client {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.leaderCli == nil {
+		ds.leaderCli = leaderclient.New(ds.detector.LeaderAddr())
+	}
+	return ds.leaderCli
+}
+
+func main() {
+	addr := flag.String("addr", ":1234", "address this node listens on")
+	role := flag.Int("role", int(detector.RoleFollower), "0=follower, 1=leader")
+	leaderAddr := flag.String("leader", "localhost:1234", "address of the current leader")
+	logLevel := flag.String("log-level", "info", "trace|debug|info|warn|error")
+	logFormat := flag.String("log-format", "text", "text|json")
+	storeKind := flag.String("store", "inmem", "inmem|redis")
+	redisAddr := flag.String("redis-addr", "localhost:6379", "redis address, used when --store=redis")
+	redisPassword := flag.String("redis-password", "", "redis password, used when --store=redis")
+	redisDB := flag.Int("redis-db", 0, "redis database index, used when --store=redis")
+	flag.Parse()
+
+	logger := logging.New(*logLevel, *logFormat)
+
+	graphStore, err := newStore(*storeKind, *redisAddr, *redisPassword, *redisDB)
+	if err != nil {
+		log.Fatal("error building store:", err)
+	}
+
+	service := NewDeadlockService(*leaderAddr, graphStore, logger)
+	service.detector.ChangeRole(detector.Role(*role), *leaderAddr)
+	if detector.Role(*role) == detector.RoleFollower {
+		service.leaderCli = leaderclient.New(*leaderAddr)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal("error starting server:", err)
+	}
+	defer listener.Close()
+
+	grpcServer := grpc.NewServer()
+	pb.RegisterDeadlockDetectorServer(grpcServer, service)
+
+	logger.Info("deadlock detector node listening", "addr", *addr, "role", service.detector.Role().String())
+	if err := grpcServer.Serve(listener); err != nil {
+		log.Fatal("serve error:", err)
+	}
+}
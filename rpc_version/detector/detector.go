@@ -0,0 +1,300 @@
+// Package detector holds the Chandy-Misra-Haas wait-for graph and
+// edge-chasing logic shared by the leader/follower gRPC nodes; it is
+// imported by cmd/server, which is the only package that ever constructs
+// a Detector.
+package detector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"rpc_version/store"
+)
+
+// Role identifies whether a Detector is the cluster's single leader or one
+// of its followers. A follower forwards every request to the leader and
+// records none of it in its own Detector; the leader holds the full graph
+// and is the only node that ever runs edge-chasing over it.
+type Role int32
+
+const (
+	RoleFollower Role = 0
+	RoleLeader   Role = 1
+)
+
+func (r Role) String() string {
+	if r == RoleLeader {
+		return "leader"
+	}
+	return "follower"
+}
+
+// Mode selects which Chandy-Misra-Haas variant a wait applies: AND means
+// the waiter is blocked on every one of its holders (all must clear for
+// it to proceed), OR means it is blocked on any one of them (the first to
+// clear unblocks it).
+type Mode int32
+
+const (
+	ModeAND Mode = 0
+	ModeOR  Mode = 1
+)
+
+// Txn is a blocked entity in the wait-for graph. StartTS is its logical
+// start time (lower is older); Priority is an external hint that breaks
+// ties between transactions started at the same instant. Txn metadata is
+// kept in the Detector itself rather than the Store, since victim
+// selection is a detection-time concern, not part of the durable graph.
+type Txn struct {
+	ID       int64
+	StartTS  int64
+	Priority int32
+}
+
+// Detector holds one node's view of the wait-for graph: for the leader it
+// is the global graph used for cycle detection; cmd/server adds no edges
+// to a follower's Detector, since a follower forwards every request. The
+// graph itself lives in a
+// pluggable store.Store so it survives a restart and, with the redis
+// backend, is shared live across every replica.
+type Detector struct {
+	store store.Store
+
+	mu         sync.Mutex
+	role       Role
+	txns       map[int64]Txn
+	leaderAddr string
+}
+
+// NewDetector creates a Detector starting out as a follower pointed at
+// leaderAddr, backed by s; ChangeRole promotes or re-targets it later.
+func NewDetector(leaderAddr string, s store.Store) *Detector {
+	return &Detector{
+		store:      s,
+		role:       RoleFollower,
+		txns:       make(map[int64]Txn),
+		leaderAddr: leaderAddr,
+	}
+}
+
+// ChangeRole switches the node between leader and follower, or repoints a
+// follower at a newly elected leader. It never touches the graph itself.
+func (d *Detector) ChangeRole(role Role, leaderAddr string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.role = role
+	d.leaderAddr = leaderAddr
+}
+
+func (d *Detector) Role() Role {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.role
+}
+
+func (d *Detector) LeaderAddr() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.leaderAddr
+}
+
+// AddWaitFor records that waiter is blocked on holder, registering or
+// refreshing waiter's Txn metadata so later victim selection can see its
+// StartTS/Priority, and persisting the edge itself to the store.
+func (d *Detector) AddWaitFor(ctx context.Context, waiter Txn, holder int64) error {
+	d.mu.Lock()
+	d.txns[waiter.ID] = waiter
+	d.mu.Unlock()
+
+	return d.store.AddEdge(ctx, waiter.ID, holder)
+}
+
+// RemoveWaitFor removes a single edge, used for CleanUpWaitFor.
+func (d *Detector) RemoveWaitFor(ctx context.Context, waiter, holder int64) error {
+	return d.store.RemoveEdge(ctx, waiter, holder)
+}
+
+// CleanUp removes every edge that mentions txn, as either waiter or
+// holder, and forgets its Txn metadata; used once a transaction commits
+// or aborts.
+func (d *Detector) CleanUp(ctx context.Context, txn int64) error {
+	d.mu.Lock()
+	delete(d.txns, txn)
+	d.mu.Unlock()
+
+	return d.store.RemoveProcess(ctx, txn)
+}
+
+// Detect looks for a deadlock involving waiter under the given Mode: AND
+// declares one as soon as any outgoing edge leads back to waiter, OR
+// requires every outgoing edge to lead back to waiter (a "quorum" of
+// confirmations across all of waiter's branches) before declaring one.
+// On success it returns the cycle and the victim chosen to be aborted.
+// Neighbor lookups within a single Detect call are cached, so a node
+// visited from more than one branch costs one store round-trip.
+func (d *Detector) Detect(ctx context.Context, waiter int64, mode Mode) (cycle []int64, victim int64, found bool, err error) {
+	c := &detectCall{ctx: ctx, store: d.store, cache: make(map[int64][]int64)}
+
+	switch mode {
+	case ModeOR:
+		cycle, found, err = c.detectOR(waiter)
+	default:
+		cycle, found, err = c.detectAND(waiter)
+	}
+	if err != nil || !found {
+		return nil, 0, false, err
+	}
+	return cycle, d.selectVictim(cycle), true, nil
+}
+
+// detectCall scopes the per-request neighbor cache to a single Detect
+// invocation.
+type detectCall struct {
+	ctx   context.Context
+	store store.Store
+	cache map[int64][]int64
+}
+
+func (c *detectCall) neighbors(id int64) ([]int64, error) {
+	if n, ok := c.cache[id]; ok {
+		return n, nil
+	}
+	n, err := c.store.Neighbors(c.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.cache[id] = n
+	return n, nil
+}
+
+func (c *detectCall) detectAND(waiter int64) (cycle []int64, found bool, err error) {
+	holders, err := c.neighbors(waiter)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, holder := range holders {
+		path, ok, err := c.pathBackTo(waiter, holder)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			// path already ends with waiter (pathBackTo walks back to it),
+			// so drop that trailing copy before prepending it once.
+			return append([]int64{waiter}, path[:len(path)-1]...), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// detectOR declares a deadlock only once every one of waiter's branches
+// independently confirms a path back to waiter, modeling the quorum of
+// probe replies the OR variant waits to collect before giving up.
+func (c *detectCall) detectOR(waiter int64) (cycle []int64, found bool, err error) {
+	holders, err := c.neighbors(waiter)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(holders) == 0 {
+		return nil, false, nil
+	}
+
+	merged := map[int64]bool{waiter: true}
+	for _, holder := range holders {
+		path, ok, err := c.pathBackTo(waiter, holder)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return nil, false, nil
+		}
+		for _, node := range path {
+			merged[node] = true
+		}
+	}
+
+	cycle = make([]int64, 0, len(merged))
+	for node := range merged {
+		cycle = append(cycle, node)
+	}
+	sort.Slice(cycle, func(i, j int) bool { return cycle[i] < cycle[j] })
+	return cycle, true, nil
+}
+
+// pathBackTo runs DFS from start looking for a path that returns to
+// target, returning the visited path (starting at start and ending with
+// target itself) on success.
+func (c *detectCall) pathBackTo(target, start int64) ([]int64, bool, error) {
+	visited := make(map[int64]bool)
+	var path []int64
+	var walkErr error
+
+	var dfs func(node int64) bool
+	dfs = func(node int64) bool {
+		path = append(path, node)
+		if node == target {
+			return true
+		}
+		if visited[node] {
+			path = path[:len(path)-1]
+			return false
+		}
+		visited[node] = true
+		holders, err := c.neighbors(node)
+		if err != nil {
+			walkErr = err
+			return false
+		}
+		for _, holder := range holders {
+			if dfs(holder) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+
+	if !dfs(start) {
+		return nil, false, walkErr
+	}
+	return path, true, nil
+}
+
+// selectVictim picks the transaction to abort out of a detected cycle:
+// the youngest (highest StartTS) loses, ties broken in favor of aborting
+// the lower-Priority transaction so higher-priority work survives.
+func (d *Detector) selectVictim(cycle []int64) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	victim := cycle[0]
+	victimTxn := d.txns[victim]
+	for _, id := range cycle[1:] {
+		txn := d.txns[id]
+		switch {
+		case txn.StartTS > victimTxn.StartTS:
+			victim, victimTxn = id, txn
+		case txn.StartTS == victimTxn.StartTS && txn.Priority < victimTxn.Priority:
+			victim, victimTxn = id, txn
+		}
+	}
+	return victim
+}
+
+// KeyHashFor returns a deterministic hash of a detected cycle, used to tag
+// the DeadlockResponse sent back over the wire.
+func KeyHashFor(cycle []int64) uint64 {
+	sorted := append([]int64{}, cycle...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var h uint64 = 14695981039346656037 // FNV offset basis
+	for _, txn := range sorted {
+		h ^= uint64(txn)
+		h *= 1099511628211 // FNV prime
+	}
+	return h
+}
+
+func (d *Detector) String() string {
+	return fmt.Sprintf("detector(role=%s, leader=%s)", d.Role(), d.LeaderAddr())
+}
@@ -0,0 +1,223 @@
+// Package leaderclient is a follower's persistent connection to whichever
+// node is currently the leader; it is shared by cmd/server (to forward
+// requests it cannot serve locally) and cmd/client (to reach the cluster
+// without caring which node answers).
+package leaderclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"rpc_version/pb"
+)
+
+// reconnectBackoff is how long dial waits between failed attempts to
+// build a connection to the leader. Send's retry after a broken Detect
+// stream does not wait.
+const reconnectBackoff = 500 * time.Millisecond
+
+// Client is a follower's persistent connection to whichever node is
+// currently the leader. Send transparently redials and replays the
+// request if the stream has gone bad, so callers never see a
+// leader-change as an error.
+//
+// The one Detect stream is shared by every concurrent local-client stream
+// this follower is serving, but grpc-go forbids calling SendMsg or RecvMsg
+// concurrently from multiple goroutines on the same stream. sendMu
+// serializes the Send half; the Recv half is owned entirely by recvLoop,
+// which demultiplexes each DeadlockResponse back to its caller by
+// RequestId so one goroutine can never receive another's reply.
+type Client struct {
+	addr string
+
+	mu      sync.Mutex
+	conn    *grpc.ClientConn
+	client  pb.DeadlockDetectorClient
+	stream  pb.DeadlockDetector_DetectClient
+	nextID  uint64
+	pending map[uint64]chan *pb.DeadlockResponse
+
+	sendMu sync.Mutex
+}
+
+// New builds a Client pointed at addr; it dials lazily on first use.
+func New(addr string) *Client {
+	return &Client{addr: addr, pending: make(map[uint64]chan *pb.DeadlockResponse)}
+}
+
+func (lc *Client) Close() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.conn != nil {
+		lc.conn.Close()
+		lc.conn = nil
+	}
+	lc.stream = nil
+}
+
+// Send forwards req to the leader over the persistent Detect stream,
+// redialing once and retrying if the stream has broken.
+func (lc *Client) Send(ctx context.Context, req *pb.DeadlockRequest) (*pb.DeadlockResponse, error) {
+	res, err := lc.sendOnce(ctx, req)
+	if err != nil {
+		res, err = lc.sendOnce(ctx, req)
+	}
+	return res, err
+}
+
+// sendOnce registers req's RequestId with recvLoop before writing it to
+// the stream, so the reply can't arrive and be dropped as unrecognized
+// before the caller starts waiting for it.
+func (lc *Client) sendOnce(ctx context.Context, req *pb.DeadlockRequest) (*pb.DeadlockResponse, error) {
+	stream, err := lc.streamFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id, respCh := lc.register()
+	defer lc.unregister(id)
+	req.RequestId = id
+
+	lc.sendMu.Lock()
+	err = stream.Send(req)
+	lc.sendMu.Unlock()
+	if err != nil {
+		lc.dropStream(stream)
+		return nil, err
+	}
+
+	select {
+	case res, ok := <-respCh:
+		if !ok {
+			return nil, fmt.Errorf("leaderclient: stream closed while waiting for response")
+		}
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AddProcess forwards an AddProcess call to the leader, dialing it first
+// if this is the first call made on this client.
+func (lc *Client) AddProcess(ctx context.Context, req *pb.AddProcessRequest) (*pb.AddProcessResponse, error) {
+	if _, err := lc.streamFor(ctx); err != nil {
+		return nil, err
+	}
+	return lc.client.AddProcess(ctx, req)
+}
+
+// register allocates a fresh RequestId and the channel recvLoop will use
+// to hand this caller its matching DeadlockResponse.
+func (lc *Client) register() (uint64, chan *pb.DeadlockResponse) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.nextID++
+	id := lc.nextID
+	ch := make(chan *pb.DeadlockResponse, 1)
+	lc.pending[id] = ch
+	return id, ch
+}
+
+func (lc *Client) unregister(id uint64) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	delete(lc.pending, id)
+}
+
+// dropStream clears lc.stream only if it still points at stream, so a
+// recvLoop noticing a dead stream can't clobber a newer one that Send
+// already redialed to in the meantime.
+func (lc *Client) dropStream(stream pb.DeadlockDetector_DetectClient) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.stream == stream {
+		lc.stream = nil
+	}
+}
+
+// streamFor lazily dials the leader and opens the persistent Detect
+// stream, retrying the dial on failure with a fixed backoff.
+func (lc *Client) streamFor(ctx context.Context) (pb.DeadlockDetector_DetectClient, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.stream != nil {
+		return lc.stream, nil
+	}
+
+	if lc.conn == nil {
+		conn, err := lc.dial()
+		if err != nil {
+			return nil, err
+		}
+		lc.conn = conn
+		lc.client = pb.NewDeadlockDetectorClient(conn)
+	}
+
+	stream, err := lc.client.Detect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lc.stream = stream
+	go lc.recvLoop(stream)
+	return stream, nil
+}
+
+// recvLoop owns Recv for one stream generation, dispatching each
+// DeadlockResponse to the caller waiting on the matching RequestId. It
+// runs until the stream breaks, at which point it drops the stream and
+// fails every still-pending caller instead of leaving them blocked
+// forever on a reply that will never come.
+func (lc *Client) recvLoop(stream pb.DeadlockDetector_DetectClient) {
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			lc.dropStream(stream)
+			lc.failPending()
+			return
+		}
+		lc.deliver(res)
+	}
+}
+
+func (lc *Client) deliver(res *pb.DeadlockResponse) {
+	lc.mu.Lock()
+	ch, ok := lc.pending[res.RequestId]
+	if ok {
+		delete(lc.pending, res.RequestId)
+	}
+	lc.mu.Unlock()
+	if ok {
+		ch <- res
+	}
+}
+
+func (lc *Client) failPending() {
+	lc.mu.Lock()
+	pending := lc.pending
+	lc.pending = make(map[uint64]chan *pb.DeadlockResponse)
+	lc.mu.Unlock()
+	for _, ch := range pending {
+		close(ch)
+	}
+}
+
+func (lc *Client) dial() (*grpc.ClientConn, error) {
+	var conn *grpc.ClientConn
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		conn, err = grpc.NewClient(lc.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err == nil {
+			return conn, nil
+		}
+		log.Printf("leaderclient: dial %s failed (attempt %d): %v", lc.addr, attempt+1, err)
+		time.Sleep(reconnectBackoff)
+	}
+	return nil, err
+}
@@ -0,0 +1,68 @@
+package leaderclient
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"rpc_version/pb"
+)
+
+// fakeDetectStream stands in for the grpc-generated
+// pb.DeadlockDetector_DetectClient without a real connection: Send
+// replies on a shared channel after a varying delay, so
+// replies arrive out of order and a test can tell whether Send's caller
+// got its own response or one meant for a different goroutine.
+type fakeDetectStream struct {
+	grpc.ClientStream
+
+	replyCh chan *pb.DeadlockResponse
+}
+
+func newFakeDetectStream() *fakeDetectStream {
+	return &fakeDetectStream{replyCh: make(chan *pb.DeadlockResponse, 64)}
+}
+
+func (f *fakeDetectStream) Send(req *pb.DeadlockRequest) error {
+	go func(req *pb.DeadlockRequest) {
+		time.Sleep(time.Duration(req.WaitForTxn%5) * time.Millisecond)
+		f.replyCh <- &pb.DeadlockResponse{RequestId: req.RequestId, Victim: req.WaitForTxn}
+	}(req)
+	return nil
+}
+
+func (f *fakeDetectStream) Recv() (*pb.DeadlockResponse, error) {
+	return <-f.replyCh, nil
+}
+
+// TestSendDemultiplexesConcurrentCallers drives many concurrent Send calls
+// over one shared stream and checks each caller gets back the response
+// carrying its own RequestId, not a reply meant for another goroutine.
+func TestSendDemultiplexesConcurrentCallers(t *testing.T) {
+	lc := New("unused")
+	stream := newFakeDetectStream()
+	lc.stream = stream
+	go lc.recvLoop(stream)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := int64(0); i < n; i++ {
+		wg.Add(1)
+		go func(waiter int64) {
+			defer wg.Done()
+			req := &pb.DeadlockRequest{WaitForTxn: waiter}
+			res, err := lc.Send(context.Background(), req)
+			if err != nil {
+				t.Errorf("Send(%d): %v", waiter, err)
+				return
+			}
+			if res.Victim != waiter {
+				t.Errorf("Send(%d) got response for caller %d instead of its own", waiter, res.Victim)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,32 @@
+package pb
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// jsonCodec replaces grpc-go's default "proto" codec for this process.
+// None of the message types in this package implement proto.Message (they
+// are plain structs, not protoc-gen-go output), so the built-in codec
+// rejects every one of them with "message is *pb.X, want proto.Message".
+// Registering under the same "proto" name swaps it out process-wide for
+// whichever codec runs last, which is this one as long as something in
+// this package is imported before the first RPC is made.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "proto"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
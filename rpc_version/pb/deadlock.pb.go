@@ -0,0 +1,76 @@
+// Package pb contains the message types exchanged by the deadlock detector
+// cluster. They mirror proto/deadlock.proto field-for-field, but this
+// package is hand-authored rather than protoc output: there is no protoc
+// toolchain available in this build, so the types don't implement
+// proto.Message, and codec.go registers a JSON codec under grpc-go's
+// "proto" content-subtype name so the wire layer doesn't require one. Once
+// a real protoc + protoc-gen-go-grpc toolchain is available, regenerate
+// this package with `protoc --go_out=. --go-grpc_out=. proto/deadlock.proto`
+// and delete codec.go.
+package pb
+
+// DetectType enumerates the operations a follower can forward to the leader.
+type DetectType int32
+
+const (
+	DetectType_DETECT             DetectType = 0
+	DetectType_CLEAN_UP           DetectType = 1
+	DetectType_CLEAN_UP_WAIT_FOR  DetectType = 2
+)
+
+// WaitMode selects which Chandy-Misra-Haas variant a DeadlockRequest is
+// evaluated under: AND (the waiter is blocked on every holder) or OR (the
+// waiter is blocked on any one of them).
+type WaitMode int32
+
+const (
+	WaitMode_AND WaitMode = 0
+	WaitMode_OR  WaitMode = 1
+)
+
+// DeadlockRequest is sent by a follower on behalf of a local client, or
+// forwarded follower-to-leader on the persistent Detect stream.
+type DeadlockRequest struct {
+	Type       DetectType
+	WaitForTxn int64
+	LockTxn    int64
+	KeyHash    uint64
+	StartTs    int64
+	Priority   int32
+	Mode       WaitMode
+	// RequestId correlates a DeadlockResponse back to the call that sent
+	// this request; set by leaderclient.Client.
+	RequestId uint64
+}
+
+// DeadlockResponse carries the leader's verdict for a single DeadlockRequest.
+type DeadlockResponse struct {
+	KeyHash          uint64
+	DeadlockDetected bool
+	Victim           int64
+	Cycle            []int64
+	// RequestId echoes the DeadlockRequest this response answers.
+	RequestId uint64
+}
+
+// WaitForEntry is a single edge in the global wait-for graph. No RPC of
+// DeadlockDetector sends or receives it.
+type WaitForEntry struct {
+	Waiter  int64
+	Holder  int64
+	KeyHash uint64
+}
+
+type ChangeRoleRequest struct {
+	Role       int32
+	LeaderAddr string
+}
+
+type ChangeRoleResponse struct{}
+
+type AddProcessRequest struct {
+	ID        int64
+	Neighbors []int64
+}
+
+type AddProcessResponse struct{}
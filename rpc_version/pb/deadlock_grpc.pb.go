@@ -0,0 +1,184 @@
+// Hand-authored to mirror protoc-gen-go-grpc output for proto/deadlock.proto;
+// see the package doc in deadlock.pb.go for why. Regenerate and delete once
+// protoc is available.
+
+package pb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeadlockDetectorClient is the client API for the DeadlockDetector service.
+type DeadlockDetectorClient interface {
+	Detect(ctx context.Context, opts ...grpc.CallOption) (DeadlockDetector_DetectClient, error)
+	AddProcess(ctx context.Context, in *AddProcessRequest, opts ...grpc.CallOption) (*AddProcessResponse, error)
+	ChangeRole(ctx context.Context, in *ChangeRoleRequest, opts ...grpc.CallOption) (*ChangeRoleResponse, error)
+}
+
+type deadlockDetectorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewDeadlockDetectorClient wraps a dialed connection with the generated
+// client stubs.
+func NewDeadlockDetectorClient(cc grpc.ClientConnInterface) DeadlockDetectorClient {
+	return &deadlockDetectorClient{cc}
+}
+
+// DeadlockDetector_DetectClient is the client side of the persistent,
+// bidirectional Detect stream.
+type DeadlockDetector_DetectClient interface {
+	Send(*DeadlockRequest) error
+	Recv() (*DeadlockResponse, error)
+	grpc.ClientStream
+}
+
+func (c *deadlockDetectorClient) Detect(ctx context.Context, opts ...grpc.CallOption) (DeadlockDetector_DetectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DeadlockDetector_ServiceDesc.Streams[0], "/deadlock.DeadlockDetector/Detect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &deadlockDetectorDetectClient{stream}, nil
+}
+
+type deadlockDetectorDetectClient struct {
+	grpc.ClientStream
+}
+
+func (x *deadlockDetectorDetectClient) Send(m *DeadlockRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *deadlockDetectorDetectClient) Recv() (*DeadlockResponse, error) {
+	m := new(DeadlockResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *deadlockDetectorClient) AddProcess(ctx context.Context, in *AddProcessRequest, opts ...grpc.CallOption) (*AddProcessResponse, error) {
+	out := new(AddProcessResponse)
+	if err := c.cc.Invoke(ctx, "/deadlock.DeadlockDetector/AddProcess", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deadlockDetectorClient) ChangeRole(ctx context.Context, in *ChangeRoleRequest, opts ...grpc.CallOption) (*ChangeRoleResponse, error) {
+	out := new(ChangeRoleResponse)
+	if err := c.cc.Invoke(ctx, "/deadlock.DeadlockDetector/ChangeRole", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeadlockDetectorServer is the server API for the DeadlockDetector service.
+type DeadlockDetectorServer interface {
+	Detect(DeadlockDetector_DetectServer) error
+	AddProcess(context.Context, *AddProcessRequest) (*AddProcessResponse, error)
+	ChangeRole(context.Context, *ChangeRoleRequest) (*ChangeRoleResponse, error)
+}
+
+// DeadlockDetector_DetectServer is the server side of the persistent,
+// bidirectional Detect stream.
+type DeadlockDetector_DetectServer interface {
+	Send(*DeadlockResponse) error
+	Recv() (*DeadlockRequest, error)
+	grpc.ServerStream
+}
+
+type deadlockDetectorDetectServer struct {
+	grpc.ServerStream
+}
+
+func (x *deadlockDetectorDetectServer) Send(m *DeadlockResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *deadlockDetectorDetectServer) Recv() (*DeadlockRequest, error) {
+	m := new(DeadlockRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DeadlockDetector_Detect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DeadlockDetectorServer).Detect(&deadlockDetectorDetectServer{stream})
+}
+
+func _DeadlockDetector_AddProcess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeadlockDetectorServer).AddProcess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/deadlock.DeadlockDetector/AddProcess"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeadlockDetectorServer).AddProcess(ctx, req.(*AddProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeadlockDetector_ChangeRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeadlockDetectorServer).ChangeRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/deadlock.DeadlockDetector/ChangeRole"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeadlockDetectorServer).ChangeRole(ctx, req.(*ChangeRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RegisterDeadlockDetectorServer registers srv on s for the
+// DeadlockDetector service.
+func RegisterDeadlockDetectorServer(s grpc.ServiceRegistrar, srv DeadlockDetectorServer) {
+	s.RegisterService(&DeadlockDetector_ServiceDesc, srv)
+}
+
+// UnimplementedDeadlockDetectorServer can be embedded in a server
+// implementation to satisfy DeadlockDetectorServer for methods it does not
+// override, and to keep new RPCs from breaking compilation.
+type UnimplementedDeadlockDetectorServer struct{}
+
+func (UnimplementedDeadlockDetectorServer) Detect(DeadlockDetector_DetectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Detect not implemented")
+}
+func (UnimplementedDeadlockDetectorServer) AddProcess(context.Context, *AddProcessRequest) (*AddProcessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddProcess not implemented")
+}
+func (UnimplementedDeadlockDetectorServer) ChangeRole(context.Context, *ChangeRoleRequest) (*ChangeRoleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeRole not implemented")
+}
+
+// DeadlockDetector_ServiceDesc is the grpc.ServiceDesc for the
+// DeadlockDetector service, used to register the implementation.
+var DeadlockDetector_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "deadlock.DeadlockDetector",
+	HandlerType: (*DeadlockDetectorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "AddProcess", Handler: _DeadlockDetector_AddProcess_Handler},
+		{MethodName: "ChangeRole", Handler: _DeadlockDetector_ChangeRole_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Detect",
+			Handler:       _DeadlockDetector_Detect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/deadlock.proto",
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemStore is the original, non-durable behavior: the graph lives only
+// in a map guarded by an RWMutex and is lost on restart.
+type InMemStore struct {
+	mu    sync.RWMutex
+	graph map[int64]map[int64]bool
+}
+
+// NewInMemStore builds an empty in-memory Store.
+func NewInMemStore() *InMemStore {
+	return &InMemStore{graph: make(map[int64]map[int64]bool)}
+}
+
+func (s *InMemStore) AddProcess(ctx context.Context, id int64, neighbors []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := make(map[int64]bool, len(neighbors))
+	for _, n := range neighbors {
+		set[n] = true
+	}
+	s.graph[id] = set
+	return nil
+}
+
+func (s *InMemStore) RemoveProcess(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.graph, id)
+	for _, neighbors := range s.graph {
+		delete(neighbors, id)
+	}
+	return nil
+}
+
+func (s *InMemStore) AddEdge(ctx context.Context, waiter, holder int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.graph[waiter] == nil {
+		s.graph[waiter] = make(map[int64]bool)
+	}
+	s.graph[waiter][holder] = true
+	return nil
+}
+
+func (s *InMemStore) RemoveEdge(ctx context.Context, waiter, holder int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.graph[waiter], holder)
+	return nil
+}
+
+func (s *InMemStore) Neighbors(ctx context.Context, waiter int64) ([]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	neighbors := make([]int64, 0, len(s.graph[waiter]))
+	for n := range s.graph[waiter] {
+		neighbors = append(neighbors, n)
+	}
+	return neighbors, nil
+}
+
+func (s *InMemStore) Snapshot(ctx context.Context) (map[int64][]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[int64][]int64, len(s.graph))
+	for id, neighbors := range s.graph {
+		list := make([]int64, 0, len(neighbors))
+		for n := range neighbors {
+			list = append(list, n)
+		}
+		snapshot[id] = list
+	}
+	return snapshot, nil
+}
+
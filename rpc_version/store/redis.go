@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func procKey(id int64) string {
+	return fmt.Sprintf("dd:proc:%d", id)
+}
+
+// RedisStore is the durable Store backend: each process is a Redis SET
+// keyed dd:proc:<id>, so the graph survives a node restart.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore wraps an already-configured *redis.Client.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) AddProcess(ctx context.Context, id int64, neighbors []int64) error {
+	pipe := s.client.TxPipeline()
+	key := procKey(id)
+	pipe.Del(ctx, key)
+	if len(neighbors) > 0 {
+		members := make([]interface{}, len(neighbors))
+		for i, n := range neighbors {
+			members[i] = n
+		}
+		pipe.SAdd(ctx, key, members...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("store: AddProcess %d: %w", id, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) RemoveProcess(ctx context.Context, id int64) error {
+	keys, err := s.client.Keys(ctx, "dd:proc:*").Result()
+	if err != nil {
+		return fmt.Errorf("store: RemoveProcess %d: %w", id, err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, procKey(id))
+	for _, key := range keys {
+		pipe.SRem(ctx, key, id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("store: RemoveProcess %d: %w", id, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) AddEdge(ctx context.Context, waiter, holder int64) error {
+	if err := s.client.SAdd(ctx, procKey(waiter), holder).Err(); err != nil {
+		return fmt.Errorf("store: AddEdge %d->%d: %w", waiter, holder, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) RemoveEdge(ctx context.Context, waiter, holder int64) error {
+	if err := s.client.SRem(ctx, procKey(waiter), holder).Err(); err != nil {
+		return fmt.Errorf("store: RemoveEdge %d->%d: %w", waiter, holder, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Neighbors(ctx context.Context, waiter int64) ([]int64, error) {
+	members, err := s.client.SMembers(ctx, procKey(waiter)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("store: Neighbors %d: %w", waiter, err)
+	}
+	return parseInt64s(members)
+}
+
+func (s *RedisStore) Snapshot(ctx context.Context) (map[int64][]int64, error) {
+	keys, err := s.client.Keys(ctx, "dd:proc:*").Result()
+	if err != nil {
+		return nil, fmt.Errorf("store: Snapshot: %w", err)
+	}
+
+	snapshot := make(map[int64][]int64, len(keys))
+	for _, key := range keys {
+		id, err := strconv.ParseInt(strings.TrimPrefix(key, "dd:proc:"), 10, 64)
+		if err != nil {
+			continue
+		}
+		members, err := s.client.SMembers(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("store: Snapshot %s: %w", key, err)
+		}
+		neighbors, err := parseInt64s(members)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[id] = neighbors
+	}
+	return snapshot, nil
+}
+
+func parseInt64s(raw []string) ([]int64, error) {
+	out := make([]int64, 0, len(raw))
+	for _, r := range raw {
+		n, err := strconv.ParseInt(r, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("store: malformed member %q: %w", r, err)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
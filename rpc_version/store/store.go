@@ -0,0 +1,26 @@
+// Package store defines the durable wait-for graph backend DeadlockService
+// depends on, so the graph survives a node restart when backed by the
+// redis implementation instead of living only in a follower's memory.
+package store
+
+import "context"
+
+// Store is the durable wait-for graph: each process ID maps to the set of
+// process IDs it is waiting on. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// AddProcess registers id with an explicit neighbor set, replacing any
+	// it already had.
+	AddProcess(ctx context.Context, id int64, neighbors []int64) error
+	// RemoveProcess removes id and every edge that mentions it.
+	RemoveProcess(ctx context.Context, id int64) error
+	// AddEdge adds a single waiter->holder edge, registering waiter as a
+	// process if it is not already known; holder is not registered.
+	AddEdge(ctx context.Context, waiter, holder int64) error
+	// RemoveEdge removes a single waiter->holder edge.
+	RemoveEdge(ctx context.Context, waiter, holder int64) error
+	// Neighbors returns the process ids waiter is currently blocked on.
+	Neighbors(ctx context.Context, waiter int64) ([]int64, error)
+	// Snapshot returns the full graph at a point in time.
+	Snapshot(ctx context.Context) (map[int64][]int64, error)
+}